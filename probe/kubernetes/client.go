@@ -10,21 +10,33 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	apiappsv1beta1 "k8s.io/api/apps/v1beta1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	apibatchv1 "k8s.io/api/batch/v1"
 	apibatchv1beta1 "k8s.io/api/batch/v1beta1"
 	apibatchv2alpha1 "k8s.io/api/batch/v2alpha1"
 	apiv1 "k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/weaveworks/scope/report"
 )
 
 // Client keeps track of running kubernetes pods and services
@@ -38,17 +50,55 @@ type Client interface {
 	WalkCronJobs(f func(CronJob) error) error
 	WalkNamespaces(f func(NamespaceResource) error) error
 
+	// RegisterResource lets a probe track a user-defined resource (typically a CRD) not
+	// covered by the built-in kinds above. It is a no-op if the cluster doesn't serve gvr.
+	RegisterResource(gvr schema.GroupVersionResource, factory func(*unstructured.Unstructured) report.Report) error
+	// WalkCustomResources calls f for each tracked instance of a resource previously passed
+	// to RegisterResource.
+	WalkCustomResources(gvr schema.GroupVersionResource, f func(*unstructured.Unstructured) error) error
+	// CustomResourceReport merges gvr's factory output across every tracked instance.
+	CustomResourceReport(gvr schema.GroupVersionResource) (report.Report, error)
+
 	WatchPods(f func(Event, Pod))
 
-	GetLogs(namespaceID, podID string, containerNames []string) (io.ReadCloser, error)
+	// The WatchXWithResourceVersion family delivers a WatchEvent per change, including
+	// Bookmark and Error, instead of the plain (Event, T) pairs WatchPods gives you. Passing
+	// resourceVersion == "" starts watching from now; passing a previous Bookmark's
+	// ResourceVersion resumes from there without replaying the world.
+	WatchPodsWithResourceVersion(resourceVersion string, f func(WatchEvent))
+	WatchServicesWithResourceVersion(resourceVersion string, f func(WatchEvent))
+	WatchDeploymentsWithResourceVersion(resourceVersion string, f func(WatchEvent))
+	WatchStatefulSetsWithResourceVersion(resourceVersion string, f func(WatchEvent))
+	WatchDaemonSetsWithResourceVersion(resourceVersion string, f func(WatchEvent))
+	WatchNamespacesWithResourceVersion(resourceVersion string, f func(WatchEvent))
+
+	GetLogs(namespaceID, podID string, containerNames []string, opts LogOptions) (io.ReadCloser, error)
+	// GetLogsForSelector multiplexes the logs of every pod matching sel in namespace.
+	GetLogsForSelector(namespace string, sel labels.Selector, opts LogOptions) (io.ReadCloser, error)
 	DeletePod(namespaceID, podID string) error
 	ScaleUp(resource, namespaceID, id string) error
 	ScaleDown(resource, namespaceID, id string) error
+	// SetReplicas scales resource/namespaceID/id directly to n replicas.
+	SetReplicas(resource, namespaceID, id string, n int32) error
 }
 
 type client struct {
-	quit             chan struct{}
-	client           *kubernetes.Clientset
+	quit              chan struct{}
+	restConfig        *rest.Config
+	client            *kubernetes.Clientset
+	dynamicClient     dynamic.Interface
+	informerFactories map[string]informers.SharedInformerFactory
+	// clusterFactory backs the cluster-scoped resources (nodes, namespaces).
+	clusterFactory informers.SharedInformerFactory
+	// watchNamespaces and labelSelector carry ClientConfig's scoping through to
+	// watchWithResourceVersion, which isn't backed by informerFactories.
+	watchNamespaces []string
+	labelSelector   string
+
+	scaleClientMutex sync.Mutex
+	restMapper       apimeta.RESTMapper
+	scalesGetter     scale.ScalesGetter
+
 	podStore         cache.Store
 	serviceStore     cache.Store
 	deploymentStore  cache.Store
@@ -61,6 +111,16 @@ type client struct {
 
 	podWatchesMutex sync.Mutex
 	podWatches      []func(Event, Pod)
+
+	customResourcesMutex sync.Mutex
+	customResources      map[schema.GroupVersionResource]*customResourceRegistration
+}
+
+// customResourceRegistration ties a tracked custom resource's store to the factory that
+// turns its instances into report fragments.
+type customResourceRegistration struct {
+	store   cache.Store
+	factory func(*unstructured.Unstructured) report.Report
 }
 
 // ClientConfig establishes the configuration for the kubernetes client
@@ -77,6 +137,19 @@ type ClientConfig struct {
 	Token                string
 	User                 string
 	Username             string
+
+	// ResyncPeriod controls how often the WalkX informers resync their indexers. Zero
+	// disables periodic resync.
+	ResyncPeriod time.Duration
+
+	// WatchNamespaces restricts the informers backing the WalkX methods to these namespaces
+	// instead of the whole cluster. Empty means every namespace.
+	WatchNamespaces []string
+	// LabelSelector further restricts every informer's and watch's list/watch calls.
+	// Combined with WatchNamespaces, it lets an operator partition probes across a cluster.
+	// There's no equivalent FieldSelector: valid fields differ per resource type, and this
+	// config applies to every resource kind the client tracks.
+	LabelSelector string
 }
 
 // NewClient returns a usable Client. Don't forget to Stop it.
@@ -127,25 +200,92 @@ func NewClient(config ClientConfig) (Client, error) {
 	}
 
 	result := &client{
-		quit:   make(chan struct{}),
-		client: c,
+		quit:              make(chan struct{}),
+		restConfig:        restConfig,
+		client:            c,
+		informerFactories: namespaceInformerFactories(c, config),
+		clusterFactory:    clusterInformerFactory(c, config),
+		watchNamespaces:   config.WatchNamespaces,
+		labelSelector:     config.LabelSelector,
+		customResources:   map[schema.GroupVersionResource]*customResourceRegistration{},
 	}
 
-	result.podStore = NewEventStore(result.triggerPodWatches, cache.MetaNamespaceKeyFunc)
-	result.runReflectorUntil("pods", result.podStore)
-
-	result.serviceStore = result.setupStore("services")
-	result.nodeStore = result.setupStore("nodes")
-	result.namespaceStore = result.setupStore("namespaces")
-	result.deploymentStore = result.setupStore("deployments")
-	result.daemonSetStore = result.setupStore("daemonsets")
-	result.jobStore = result.setupStore("jobs")
-	result.statefulSetStore = result.setupStore("statefulsets")
-	result.cronJobStore = result.setupStore("cronjobs")
+	result.podStore = result.setupInformer(
+		result.informerFactories, apiv1.SchemeGroupVersion, "pods",
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Core().V1().Pods().Informer() },
+		result.triggerPodWatches,
+	)
+	result.serviceStore = result.setupInformer(
+		result.informerFactories, apiv1.SchemeGroupVersion, "services",
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Core().V1().Services().Informer() }, nil,
+	)
+	result.deploymentStore = result.setupInformer(
+		result.informerFactories, apiextensionsv1beta1.SchemeGroupVersion, "deployments",
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Extensions().V1beta1().Deployments().Informer() }, nil,
+	)
+	result.daemonSetStore = result.setupInformer(
+		result.informerFactories, apiextensionsv1beta1.SchemeGroupVersion, "daemonsets",
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Extensions().V1beta1().DaemonSets().Informer() }, nil,
+	)
+	result.jobStore = result.setupInformer(
+		result.informerFactories, apibatchv1.SchemeGroupVersion, "jobs",
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Batch().V1().Jobs().Informer() }, nil,
+	)
+	result.statefulSetStore = result.setupInformer(
+		result.informerFactories, apiappsv1beta1.SchemeGroupVersion, "statefulsets",
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Apps().V1beta1().StatefulSets().Informer() }, nil,
+	)
+	result.cronJobStore = result.setupCronJobInformer()
+
+	clusterFactories := map[string]informers.SharedInformerFactory{metav1.NamespaceAll: result.clusterFactory}
+	result.nodeStore = result.setupInformer(
+		clusterFactories, apiv1.SchemeGroupVersion, "nodes",
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Core().V1().Nodes().Informer() }, nil,
+	)
+	result.namespaceStore = result.setupInformer(
+		clusterFactories, apiv1.SchemeGroupVersion, "namespaces",
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Core().V1().Namespaces().Informer() }, nil,
+	)
+
+	for _, factory := range result.informerFactories {
+		factory.Start(result.quit)
+	}
+	result.clusterFactory.Start(result.quit)
 
 	return result, nil
 }
 
+// tweakListOptions applies config's label selector to every list/watch an informer factory
+// makes.
+func tweakListOptions(config ClientConfig) informers.SharedInformerOption {
+	return informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.LabelSelector = config.LabelSelector
+	})
+}
+
+// namespaceInformerFactories returns one SharedInformerFactory per namespace named in
+// config.WatchNamespaces, or a single NamespaceAll factory when it's empty.
+func namespaceInformerFactories(c kubernetes.Interface, config ClientConfig) map[string]informers.SharedInformerFactory {
+	namespaces := config.WatchNamespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	factories := make(map[string]informers.SharedInformerFactory, len(namespaces))
+	for _, namespace := range namespaces {
+		factories[namespace] = informers.NewSharedInformerFactoryWithOptions(
+			c, config.ResyncPeriod, informers.WithNamespace(namespace), tweakListOptions(config),
+		)
+	}
+	return factories
+}
+
+// clusterInformerFactory builds the factory used for cluster-scoped resources (nodes,
+// namespaces), ignoring ClientConfig.WatchNamespaces.
+func clusterInformerFactory(c kubernetes.Interface, config ClientConfig) informers.SharedInformerFactory {
+	return informers.NewSharedInformerFactoryWithOptions(c, config.ResyncPeriod, tweakListOptions(config))
+}
+
 func (c *client) isResourceSupported(groupVersion schema.GroupVersion, resource string) (bool, error) {
 	resourceList, err := c.client.Discovery().ServerResourcesForGroupVersion(groupVersion.String())
 	if err != nil {
@@ -164,67 +304,184 @@ func (c *client) isResourceSupported(groupVersion schema.GroupVersion, resource
 	return false, nil
 }
 
-func (c *client) setupStore(resource string) cache.Store {
-	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
-	c.runReflectorUntil(resource, store)
-	return store
+// multiNamespaceStore presents several per-namespace stores as a single cache.Store.
+type multiNamespaceStore struct {
+	byNamespace map[string]cache.Store
 }
 
-func (c *client) clientAndType(resource string) (rest.Interface, interface{}, error) {
-	switch resource {
-	case "pods":
-		return c.client.CoreV1().RESTClient(), &apiv1.Pod{}, nil
-	case "services":
-		return c.client.CoreV1().RESTClient(), &apiv1.Service{}, nil
-	case "nodes":
-		return c.client.CoreV1().RESTClient(), &apiv1.Node{}, nil
-	case "namespaces":
-		return c.client.CoreV1().RESTClient(), &apiv1.Namespace{}, nil
-	case "deployments":
-		return c.client.ExtensionsV1beta1().RESTClient(), &apiextensionsv1beta1.Deployment{}, nil
-	case "daemonsets":
-		return c.client.ExtensionsV1beta1().RESTClient(), &apiextensionsv1beta1.DaemonSet{}, nil
-	case "jobs":
-		return c.client.BatchV1().RESTClient(), &apibatchv1.Job{}, nil
-	case "statefulsets":
-		return c.client.AppsV1beta1().RESTClient(), &apiappsv1beta1.StatefulSet{}, nil
-	case "cronjobs":
-		ok, err := c.isResourceSupported(c.client.BatchV1beta1().RESTClient().APIVersion(), resource)
-		if err != nil {
-			return nil, nil, err
+// newMultiNamespaceStore returns byNamespace's lone store directly when there's only one,
+// so the common case doesn't pay for the indirection.
+func newMultiNamespaceStore(byNamespace map[string]cache.Store) cache.Store {
+	if len(byNamespace) == 1 {
+		for _, store := range byNamespace {
+			return store
 		}
-		if ok {
-			// kubernetes >= 1.8
-			return c.client.BatchV1beta1().RESTClient(), &apibatchv1beta1.CronJob{}, nil
+	}
+	return multiNamespaceStore{byNamespace: byNamespace}
+}
+
+func (s multiNamespaceStore) storeFor(obj interface{}) cache.Store {
+	if accessor, err := apimeta.Accessor(obj); err == nil {
+		if store, ok := s.byNamespace[accessor.GetNamespace()]; ok {
+			return store
 		}
-		// kubernetes < 1.8
-		return c.client.BatchV2alpha1().RESTClient(), &apibatchv2alpha1.CronJob{}, nil
 	}
-	return nil, nil, fmt.Errorf("Invalid resource: %v", resource)
+	for _, store := range s.byNamespace {
+		return store
+	}
+	return cache.NewStore(cache.MetaNamespaceKeyFunc)
 }
 
-// runReflectorUntil runs cache.Reflector#ListAndWatch in an endless loop, after checking that the resource is supported by kubernetes.
-// Errors are logged and retried with exponential backoff.
-func (c *client) runReflectorUntil(resource string, store cache.Store) {
-	var r *cache.Reflector
-	listAndWatch := func() (bool, error) {
-		if r == nil {
-			kclient, itemType, err := c.clientAndType(resource)
-			if err != nil {
-				return false, err
-			}
-			ok, err := c.isResourceSupported(kclient.APIVersion(), resource)
-			if err != nil {
-				return false, err
-			}
-			if !ok {
-				log.Infof("%v are not supported by this Kubernetes version", resource)
-				return true, nil
-			}
-			lw := cache.NewListWatchFromClient(kclient, resource, metav1.NamespaceAll, fields.Everything())
-			r = cache.NewReflector(lw, itemType, store, 0)
+func (s multiNamespaceStore) Add(obj interface{}) error    { return s.storeFor(obj).Add(obj) }
+func (s multiNamespaceStore) Update(obj interface{}) error { return s.storeFor(obj).Update(obj) }
+func (s multiNamespaceStore) Delete(obj interface{}) error { return s.storeFor(obj).Delete(obj) }
+
+func (s multiNamespaceStore) List() []interface{} {
+	var all []interface{}
+	for _, store := range s.byNamespace {
+		all = append(all, store.List()...)
+	}
+	return all
+}
+
+func (s multiNamespaceStore) ListKeys() []string {
+	var keys []string
+	for _, store := range s.byNamespace {
+		keys = append(keys, store.ListKeys()...)
+	}
+	return keys
+}
+
+func (s multiNamespaceStore) Get(obj interface{}) (item interface{}, exists bool, err error) {
+	return s.storeFor(obj).Get(obj)
+}
+
+func (s multiNamespaceStore) GetByKey(key string) (item interface{}, exists bool, err error) {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	store, ok := s.byNamespace[namespace]
+	if !ok {
+		return nil, false, nil
+	}
+	return store.GetByKey(key)
+}
+
+func (s multiNamespaceStore) Replace([]interface{}, string) error {
+	return fmt.Errorf("multiNamespaceStore: Replace is not supported, it belongs to the underlying per-namespace stores")
+}
+
+func (s multiNamespaceStore) Resync() error {
+	for _, store := range s.byNamespace {
+		if err := store.Resync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setupInformer checks that the cluster serves resource, then registers handler (if non-nil)
+// for add/update/delete on the informer informerFor returns from every factory in factories,
+// presenting their indexers as a single cache.Store.
+func (c *client) setupInformer(factories map[string]informers.SharedInformerFactory, groupVersion schema.GroupVersion, resource string, informerFor func(informers.SharedInformerFactory) cache.SharedIndexInformer, handler func(Event, interface{})) cache.Store {
+	ok, err := c.isResourceSupported(groupVersion, resource)
+	if err != nil {
+		log.Errorf("kubernetes: error checking support for %s: %v", resource, err)
+		return nil
+	}
+	if !ok {
+		log.Infof("%v are not supported by this Kubernetes version", resource)
+		return nil
+	}
+
+	byNamespace := make(map[string]cache.Store, len(factories))
+	for namespace, factory := range factories {
+		informer := informerFor(factory)
+		if handler != nil {
+			informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc:    func(obj interface{}) { handler(Add, obj) },
+				UpdateFunc: func(_, obj interface{}) { handler(Update, obj) },
+				DeleteFunc: func(obj interface{}) {
+					if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+						obj = d.Obj
+					}
+					handler(Delete, obj)
+				},
+			})
 		}
+		byNamespace[namespace] = informer.GetStore()
+	}
+	return newMultiNamespaceStore(byNamespace)
+}
+
+// setupCronJobInformer picks the batch/v1beta1 or batch/v2alpha1 CronJob informer depending
+// on which one the cluster serves.
+func (c *client) setupCronJobInformer() cache.Store {
+	ok, err := c.isResourceSupported(apibatchv1beta1.SchemeGroupVersion, "cronjobs")
+	if err != nil {
+		log.Errorf("kubernetes: error checking support for cronjobs: %v", err)
+		return nil
+	}
+	if ok {
+		// kubernetes >= 1.8
+		return c.setupInformer(
+			c.informerFactories, apibatchv1beta1.SchemeGroupVersion, "cronjobs",
+			func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Batch().V1beta1().CronJobs().Informer() }, nil,
+		)
+	}
+	// kubernetes < 1.8
+	return c.setupInformer(
+		c.informerFactories, apibatchv2alpha1.SchemeGroupVersion, "cronjobs",
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer { return f.Batch().V2alpha1().CronJobs().Informer() }, nil,
+	)
+}
+
+// RegisterResource probes the discovery API for gvr and, if served, starts a reflector
+// against it via the dynamic client.
+func (c *client) RegisterResource(gvr schema.GroupVersionResource, factory func(*unstructured.Unstructured) report.Report) error {
+	if c.dynamicClient == nil {
+		dyn, err := dynamic.NewForConfig(c.restConfig)
+		if err != nil {
+			return err
+		}
+		c.dynamicClient = dyn
+	}
+
+	ok, err := c.isResourceSupported(gvr.GroupVersion(), gvr.Resource)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Infof("%v is not supported by this Kubernetes cluster", gvr)
+		return nil
+	}
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	c.customResourcesMutex.Lock()
+	c.customResources[gvr] = &customResourceRegistration{store: store, factory: factory}
+	c.customResourcesMutex.Unlock()
+
+	c.runCustomReflectorUntil(gvr, store)
+	return nil
+}
 
+// runCustomReflectorUntil runs a reflector for gvr through the dynamic client, retrying
+// ListAndWatch with exponential backoff until c.quit is closed.
+func (c *client) runCustomReflectorUntil(gvr schema.GroupVersionResource, store cache.Store) {
+	res := c.dynamicClient.Resource(gvr)
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = c.labelSelector
+			return res.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = c.labelSelector
+			return res.Watch(options)
+		},
+	}
+	r := cache.NewReflector(lw, &unstructured.Unstructured{}, store, 0)
+	listAndWatch := func() (bool, error) {
 		select {
 		case <-c.quit:
 			return true, nil
@@ -233,11 +490,44 @@ func (c *client) runReflectorUntil(resource string, store cache.Store) {
 			return false, err
 		}
 	}
-	bo := backoff.New(listAndWatch, fmt.Sprintf("Kubernetes reflector (%s)", resource))
+	bo := backoff.New(listAndWatch, fmt.Sprintf("Kubernetes reflector (%s)", gvr))
 	bo.SetMaxBackoff(5 * time.Minute)
 	go bo.Start()
 }
 
+// WalkCustomResources calls f for each instance of a resource previously passed to
+// RegisterResource.
+func (c *client) WalkCustomResources(gvr schema.GroupVersionResource, f func(*unstructured.Unstructured) error) error {
+	c.customResourcesMutex.Lock()
+	reg, ok := c.customResources[gvr]
+	c.customResourcesMutex.Unlock()
+	if !ok {
+		return fmt.Errorf("resource not registered: %v", gvr)
+	}
+	for _, m := range reg.store.List() {
+		if err := f(m.(*unstructured.Unstructured)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CustomResourceReport merges the report fragments reg's factory builds for each tracked
+// instance of gvr into a single report.Report.
+func (c *client) CustomResourceReport(gvr schema.GroupVersionResource) (report.Report, error) {
+	c.customResourcesMutex.Lock()
+	reg, ok := c.customResources[gvr]
+	c.customResourcesMutex.Unlock()
+	if !ok {
+		return report.MakeReport(), fmt.Errorf("resource not registered: %v", gvr)
+	}
+	rpt := report.MakeReport()
+	for _, m := range reg.store.List() {
+		rpt = rpt.Merge(reg.factory(m.(*unstructured.Unstructured)))
+	}
+	return rpt, nil
+}
+
 func (c *client) WatchPods(f func(Event, Pod)) {
 	c.podWatchesMutex.Lock()
 	defer c.podWatchesMutex.Unlock()
@@ -252,7 +542,137 @@ func (c *client) triggerPodWatches(e Event, pod interface{}) {
 	}
 }
 
+// WatchEvent is a structured notification from the WatchXWithResourceVersion family. Old is
+// nil for Added, New is nil for Deleted, and both are nil for Bookmark/Error.
+type WatchEvent struct {
+	Type            watch.EventType
+	Old, New        interface{}
+	ResourceVersion string
+}
+
+func (c *client) WatchPodsWithResourceVersion(resourceVersion string, f func(WatchEvent)) {
+	c.watchWithResourceVersion("pods", resourceVersion, f)
+}
+
+func (c *client) WatchServicesWithResourceVersion(resourceVersion string, f func(WatchEvent)) {
+	c.watchWithResourceVersion("services", resourceVersion, f)
+}
+
+func (c *client) WatchDeploymentsWithResourceVersion(resourceVersion string, f func(WatchEvent)) {
+	c.watchWithResourceVersion("deployments", resourceVersion, f)
+}
+
+func (c *client) WatchStatefulSetsWithResourceVersion(resourceVersion string, f func(WatchEvent)) {
+	c.watchWithResourceVersion("statefulsets", resourceVersion, f)
+}
+
+func (c *client) WatchDaemonSetsWithResourceVersion(resourceVersion string, f func(WatchEvent)) {
+	c.watchWithResourceVersion("daemonsets", resourceVersion, f)
+}
+
+func (c *client) WatchNamespacesWithResourceVersion(resourceVersion string, f func(WatchEvent)) {
+	c.watchWithResourceVersion("namespaces", resourceVersion, f)
+}
+
+// restClientForWatch resolves the typed REST client that serves resource.
+func (c *client) restClientForWatch(resource string) (rest.Interface, error) {
+	switch resource {
+	case "pods", "services", "namespaces":
+		return c.client.CoreV1().RESTClient(), nil
+	case "deployments", "daemonsets":
+		return c.client.ExtensionsV1beta1().RESTClient(), nil
+	case "statefulsets":
+		return c.client.AppsV1beta1().RESTClient(), nil
+	}
+	return nil, fmt.Errorf("invalid resource: %v", resource)
+}
+
+// namespacesForWatch returns the namespaces watchWithResourceVersion should open a stream
+// against for resource, mirroring namespaceInformerFactories: every entry in
+// ClientConfig.WatchNamespaces, or the whole cluster if that's empty. namespaces is
+// cluster-scoped itself, so it's always watched across the whole cluster regardless.
+func (c *client) namespacesForWatch(resource string) []string {
+	if resource == "namespaces" || len(c.watchNamespaces) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+	return c.watchNamespaces
+}
+
+// watchWithResourceVersion opens a raw, bookmark-enabled watch against resource starting at
+// resourceVersion ("" to start from now) and delivers every event to f. Unlike the
+// informer-backed WalkX stores, it talks directly to the watch stream so Bookmark and Error
+// events reach the caller. It fans out across namespacesForWatch, applying c.labelSelector
+// to each, the same way the WalkX informers do.
+func (c *client) watchWithResourceVersion(resource, resourceVersion string, f func(WatchEvent)) {
+	kclient, err := c.restClientForWatch(resource)
+	if err != nil {
+		log.Errorf("kubernetes: %v", err)
+		return
+	}
+	for _, namespace := range c.namespacesForWatch(resource) {
+		go c.watchNamespaceWithResourceVersion(kclient, resource, namespace, resourceVersion, f)
+	}
+}
+
+// watchNamespaceWithResourceVersion is watchWithResourceVersion's per-namespace worker.
+func (c *client) watchNamespaceWithResourceVersion(kclient rest.Interface, resource, namespace, resourceVersion string, f func(WatchEvent)) {
+	lw := cache.NewFilteredListWatchFromClient(kclient, resource, namespace, func(options *metav1.ListOptions) {
+		options.LabelSelector = c.labelSelector
+	})
+
+	rv := resourceVersion
+	last := map[string]runtime.Object{}
+	run := func() (bool, error) {
+		w, err := lw.WatchFunc(metav1.ListOptions{
+			ResourceVersion:     rv,
+			AllowWatchBookmarks: true,
+		})
+		if err != nil {
+			return false, err
+		}
+		defer w.Stop()
+
+		for {
+			select {
+			case <-c.quit:
+				return true, nil
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					return false, fmt.Errorf("kubernetes: watch of %s/%s closed", namespace, resource)
+				}
+
+				var key string
+				if accessor, ok := event.Object.(metav1.Object); ok {
+					rv = accessor.GetResourceVersion()
+					key = accessor.GetNamespace() + "/" + accessor.GetName()
+				}
+
+				we := WatchEvent{Type: event.Type, ResourceVersion: rv}
+				switch event.Type {
+				case watch.Added:
+					we.New = event.Object
+					last[key] = event.Object
+				case watch.Modified:
+					we.Old = last[key]
+					we.New = event.Object
+					last[key] = event.Object
+				case watch.Deleted:
+					we.Old = event.Object
+					delete(last, key)
+				}
+				f(we)
+			}
+		}
+	}
+	bo := backoff.New(run, fmt.Sprintf("Kubernetes watch (%s/%s)", namespace, resource))
+	bo.SetMaxBackoff(5 * time.Minute)
+	bo.Start()
+}
+
 func (c *client) WalkPods(f func(Pod) error) error {
+	if c.podStore == nil {
+		return nil
+	}
 	for _, m := range c.podStore.List() {
 		pod := m.(*apiv1.Pod)
 		if err := f(NewPod(pod)); err != nil {
@@ -263,6 +683,9 @@ func (c *client) WalkPods(f func(Pod) error) error {
 }
 
 func (c *client) WalkServices(f func(Service) error) error {
+	if c.serviceStore == nil {
+		return nil
+	}
 	for _, m := range c.serviceStore.List() {
 		s := m.(*apiv1.Service)
 		if err := f(NewService(s)); err != nil {
@@ -320,9 +743,11 @@ func (c *client) WalkCronJobs(f func(CronJob) error) error {
 	}
 	// We index jobs by id to make lookup for each cronjob more efficient
 	jobs := map[types.UID]*apibatchv1.Job{}
-	for _, m := range c.jobStore.List() {
-		j := m.(*apibatchv1.Job)
-		jobs[j.UID] = j
+	if c.jobStore != nil {
+		for _, m := range c.jobStore.List() {
+			j := m.(*apibatchv1.Job)
+			jobs[j.UID] = j
+		}
 	}
 	for _, m := range c.cronJobStore.List() {
 		if err := f(NewCronJob(m, jobs)); err != nil {
@@ -333,6 +758,9 @@ func (c *client) WalkCronJobs(f func(CronJob) error) error {
 }
 
 func (c *client) WalkNamespaces(f func(NamespaceResource) error) error {
+	if c.namespaceStore == nil {
+		return nil
+	}
 	for _, m := range c.namespaceStore.List() {
 		namespace := m.(*apiv1.Namespace)
 		if err := f(NewNamespace(namespace)); err != nil {
@@ -342,28 +770,167 @@ func (c *client) WalkNamespaces(f func(NamespaceResource) error) error {
 	return nil
 }
 
-func (c *client) GetLogs(namespaceID, podID string, containerNames []string) (io.ReadCloser, error) {
-	readClosersWithLabel := map[io.ReadCloser]string{}
+// LogOptions controls how GetLogs and GetLogsForSelector stream a container's log.
+type LogOptions struct {
+	Since      time.Duration
+	TailLines  int64
+	Previous   bool
+	Follow     bool
+	Timestamps bool
+}
+
+func (o LogOptions) toPodLogOptions(container string) *apiv1.PodLogOptions {
+	opts := &apiv1.PodLogOptions{
+		Container:  container,
+		Follow:     o.Follow,
+		Previous:   o.Previous,
+		Timestamps: o.Timestamps,
+	}
+	if o.Since > 0 {
+		since := int64(o.Since.Seconds())
+		opts.SinceSeconds = &since
+	}
+	if o.TailLines > 0 {
+		tail := o.TailLines
+		opts.TailLines = &tail
+	}
+	return opts
+}
+
+// openContainerLog opens a single container's log stream, returning alongside it a closure
+// that reopens the same stream so callers can reconnect after the kubelet closes it.
+func (c *client) openContainerLog(namespaceID, podID, container string, opts LogOptions) (io.ReadCloser, func() (io.ReadCloser, error), error) {
+	open := func() (io.ReadCloser, error) {
+		return c.client.CoreV1().Pods(namespaceID).GetLogs(podID, opts.toPodLogOptions(container)).Stream()
+	}
+	readCloser, err := open()
+	return readCloser, open, err
+}
+
+// containerNames returns the live container names of namespaceID/podID straight from the
+// pod informer, so the periodic discover poll picks up sidecars added after the stream was
+// first opened.
+func (c *client) containerNames(namespaceID, podID string) ([]string, error) {
+	obj, ok, err := c.podStore.GetByKey(namespaceID + "/" + podID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apierrors.NewNotFound(apiv1.Resource("pods"), podID)
+	}
+	pod := obj.(*apiv1.Pod)
+	names := make([]string, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		names = append(names, container.Name)
+	}
+	return names, nil
+}
+
+// GetLogs streams namespaceID/podID's containers, reconnecting through NewLogReadCloser
+// when the kubelet closes a stream (pod restart, node blip).
+func (c *client) GetLogs(namespaceID, podID string, containerNames []string, opts LogOptions) (io.ReadCloser, error) {
+	readers := map[io.ReadCloser]string{}
+	reopen := map[string]func() (io.ReadCloser, error){}
 	for _, container := range containerNames {
-		req := c.client.CoreV1().Pods(namespaceID).GetLogs(
-			podID,
-			&apiv1.PodLogOptions{
-				Follow:     true,
-				Timestamps: true,
-				Container:  container,
-			},
-		)
-		readCloser, err := req.Stream()
+		readCloser, open, err := c.openContainerLog(namespaceID, podID, container, opts)
 		if err != nil {
-			for rc := range readClosersWithLabel {
+			for rc := range readers {
 				rc.Close()
 			}
 			return nil, err
 		}
-		readClosersWithLabel[readCloser] = container
+		label := fmt.Sprintf("%s/%s:%s", namespaceID, podID, container)
+		readers[readCloser] = label
+		reopen[label] = open
+	}
+
+	requested := make(map[string]bool, len(containerNames))
+	for _, container := range containerNames {
+		requested[container] = true
+	}
+	// Containers the pod already had but the caller didn't ask for stay excluded; a container
+	// that appears later (a sidecar) is streamed too.
+	existing := map[string]bool{}
+	if names, err := c.containerNames(namespaceID, podID); err == nil {
+		for _, container := range names {
+			existing[container] = true
+		}
+	}
+
+	discover := func() (map[string]func() (io.ReadCloser, error), error) {
+		names, err := c.containerNames(namespaceID, podID)
+		if err != nil {
+			return nil, err
+		}
+		opens := make(map[string]func() (io.ReadCloser, error), len(containerNames))
+		for _, container := range names {
+			if existing[container] && !requested[container] {
+				continue
+			}
+			container := container
+			label := fmt.Sprintf("%s/%s:%s", namespaceID, podID, container)
+			opens[label] = func() (io.ReadCloser, error) {
+				rc, _, err := c.openContainerLog(namespaceID, podID, container, opts)
+				return rc, err
+			}
+		}
+		return opens, nil
+	}
+
+	return NewLogReadCloser(c.quit, readers, reopen, discover), nil
+}
+
+// GetLogsForSelector multiplexes the log streams of every pod matching sel in namespace
+// (metav1.NamespaceAll for every namespace) into a single reader, prefixing each line with
+// its pod/container label. Newly scheduled matching pods are picked up automatically.
+func (c *client) GetLogsForSelector(namespace string, sel labels.Selector, opts LogOptions) (io.ReadCloser, error) {
+	matchingPods := func() []*apiv1.Pod {
+		var pods []*apiv1.Pod
+		for _, m := range c.podStore.List() {
+			pod := m.(*apiv1.Pod)
+			if namespace != metav1.NamespaceAll && pod.Namespace != namespace {
+				continue
+			}
+			if sel.Matches(labels.Set(pod.Labels)) {
+				pods = append(pods, pod)
+			}
+		}
+		return pods
+	}
+
+	readers := map[io.ReadCloser]string{}
+	reopen := map[string]func() (io.ReadCloser, error){}
+	for _, pod := range matchingPods() {
+		for _, container := range pod.Spec.Containers {
+			readCloser, open, err := c.openContainerLog(pod.Namespace, pod.Name, container.Name, opts)
+			if err != nil {
+				for rc := range readers {
+					rc.Close()
+				}
+				return nil, err
+			}
+			label := fmt.Sprintf("%s/%s:%s", pod.Namespace, pod.Name, container.Name)
+			readers[readCloser] = label
+			reopen[label] = open
+		}
+	}
+
+	discover := func() (map[string]func() (io.ReadCloser, error), error) {
+		opens := map[string]func() (io.ReadCloser, error){}
+		for _, pod := range matchingPods() {
+			for _, container := range pod.Spec.Containers {
+				namespaceID, podID, containerName := pod.Namespace, pod.Name, container.Name
+				label := fmt.Sprintf("%s/%s:%s", namespaceID, podID, containerName)
+				opens[label] = func() (io.ReadCloser, error) {
+					rc, _, err := c.openContainerLog(namespaceID, podID, containerName, opts)
+					return rc, err
+				}
+			}
+		}
+		return opens, nil
 	}
 
-	return NewLogReadCloser(readClosersWithLabel), nil
+	return NewLogReadCloser(c.quit, readers, reopen, discover), nil
 }
 
 func (c *client) DeletePod(namespaceID, podID string) error {
@@ -371,25 +938,68 @@ func (c *client) DeletePod(namespaceID, podID string) error {
 }
 
 func (c *client) ScaleUp(resource, namespaceID, id string) error {
-	return c.modifyScale(resource, namespaceID, id, func(scale *apiextensionsv1beta1.Scale) {
+	return c.modifyScale(resource, namespaceID, id, func(scale *autoscalingv1.Scale) {
 		scale.Spec.Replicas++
 	})
 }
 
 func (c *client) ScaleDown(resource, namespaceID, id string) error {
-	return c.modifyScale(resource, namespaceID, id, func(scale *apiextensionsv1beta1.Scale) {
+	return c.modifyScale(resource, namespaceID, id, func(scale *autoscalingv1.Scale) {
 		scale.Spec.Replicas--
 	})
 }
 
-func (c *client) modifyScale(resource, namespace, id string, f func(*apiextensionsv1beta1.Scale)) error {
-	scaler := c.client.Extensions().Scales(namespace)
-	scale, err := scaler.Get(resource, id)
+// SetReplicas jumps a scalable resource straight to n replicas.
+func (c *client) SetReplicas(resource, namespaceID, id string, n int32) error {
+	return c.modifyScale(resource, namespaceID, id, func(scale *autoscalingv1.Scale) {
+		scale.Spec.Replicas = n
+	})
+}
+
+// ensureScaleClient lazily builds the polymorphic scale client and the REST mapper it needs
+// to resolve a resource name to the GroupResource its /scale subresource is served under.
+// ScaleUp/ScaleDown/SetReplicas can all race in here, so c.scaleClientMutex guards the build.
+func (c *client) ensureScaleClient() error {
+	c.scaleClientMutex.Lock()
+	defer c.scaleClientMutex.Unlock()
+
+	if c.scalesGetter != nil {
+		return nil
+	}
+	discoveryClient := c.client.Discovery()
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	resolver := scale.NewDiscoveryScaleKindResolver(discoveryClient)
+	scalesGetter, err := scale.NewForConfig(c.restConfig, mapper, dynamic.LegacyAPIPathResolverFunc, resolver)
+	if err != nil {
+		return err
+	}
+	c.restMapper = mapper
+	c.scalesGetter = scalesGetter
+	return nil
+}
+
+// modifyScale resolves resource (a plain name like "statefulsets", or a kubectl-style
+// "resource.group" pair like "rollouts.argoproj.io") to its GroupResource and round-trips
+// its /scale subresource through f, via scale.ScalesGetter so this works uniformly across
+// built-in kinds and any CRD that exposes /scale.
+func (c *client) modifyScale(resource, namespace, id string, f func(*autoscalingv1.Scale)) error {
+	if err := c.ensureScaleClient(); err != nil {
+		return err
+	}
+
+	gvr, err := c.restMapper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+	if err != nil {
+		return err
+	}
+	gr := gvr.GroupResource()
+
+	scaler := c.scalesGetter.Scales(namespace)
+	scale, err := scaler.Get(gr, id)
 	if err != nil {
 		return err
 	}
 	f(scale)
-	_, err = scaler.Update(resource, scale)
+	_, err = scaler.Update(gr, scale)
 	return err
 }
 
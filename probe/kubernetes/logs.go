@@ -0,0 +1,240 @@
+package kubernetes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/weaveworks/common/backoff"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// discoverInterval is how often logMultiplexer polls discover for containers that weren't
+// part of the original set, e.g. a sidecar added after the stream was opened.
+const discoverInterval = 30 * time.Second
+
+// NewLogReadCloser multiplexes readers - each labeled by the map's value - into a single
+// io.ReadCloser, prefixing every line with "<label>: ". When a reader ends, reopen[label]
+// reopens it with the same backoff used elsewhere in this package; discover is polled
+// periodically to pick up containers that weren't part of the original readers. Closing the
+// returned ReadCloser, or closing quit, stops every goroutine and the underlying readers.
+func NewLogReadCloser(
+	quit <-chan struct{},
+	readers map[io.ReadCloser]string,
+	reopen map[string]func() (io.ReadCloser, error),
+	discover func() (map[string]func() (io.ReadCloser, error), error),
+) io.ReadCloser {
+	pr, pw := io.Pipe()
+	m := &logMultiplexer{
+		quit:     quit,
+		done:     make(chan struct{}),
+		pw:       pw,
+		reopen:   reopen,
+		discover: discover,
+		live:     make(map[string]io.ReadCloser, len(readers)),
+	}
+
+	for rc, label := range readers {
+		m.startStream(rc, label)
+	}
+	go m.discoverLoop()
+	go m.waitAndClose()
+
+	return &logReadCloser{PipeReader: pr, stop: m.stop}
+}
+
+// logMultiplexer copies lines from a set of labeled readers into a single io.PipeWriter,
+// reopening and discovering readers as they end or appear. mu also guards stopping, so once
+// waitAndClose starts tearing down no stream can register itself with wg after the fact.
+type logMultiplexer struct {
+	quit     <-chan struct{}
+	done     chan struct{}
+	doneOnce sync.Once
+	pw       *io.PipeWriter
+	wg       sync.WaitGroup
+
+	reopen   map[string]func() (io.ReadCloser, error)
+	discover func() (map[string]func() (io.ReadCloser, error), error)
+
+	mu       sync.Mutex
+	live     map[string]io.ReadCloser
+	stopping bool
+}
+
+func (m *logMultiplexer) stop() {
+	m.doneOnce.Do(func() { close(m.done) })
+}
+
+// startStream registers rc/label and starts streaming it, unless the multiplexer is already
+// tearing down, in which case rc is closed unused.
+func (m *logMultiplexer) startStream(rc io.ReadCloser, label string) {
+	m.mu.Lock()
+	if m.stopping {
+		m.mu.Unlock()
+		rc.Close()
+		return
+	}
+	m.live[label] = rc
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	go m.stream(rc, label)
+}
+
+// waitAndClose closes every live reader once the multiplexer is stopped, so the blocking Read
+// each stream goroutine is in returns, then waits for them to exit before closing pw.
+func (m *logMultiplexer) waitAndClose() {
+	select {
+	case <-m.quit:
+	case <-m.done:
+	}
+
+	m.mu.Lock()
+	m.stopping = true
+	for _, rc := range m.live {
+		rc.Close()
+	}
+	m.mu.Unlock()
+
+	m.wg.Wait()
+	m.pw.Close()
+}
+
+func (m *logMultiplexer) stopped() bool {
+	select {
+	case <-m.quit:
+		return true
+	case <-m.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// stream copies rc's lines, labeled, into m.pw until rc ends, then reopens it (backing off on
+// a failing reopen) and keeps going until there's no reopen for label or the multiplexer stops.
+func (m *logMultiplexer) stream(rc io.ReadCloser, label string) {
+	defer m.wg.Done()
+	for {
+		m.copyLines(rc, label)
+		rc.Close()
+
+		m.mu.Lock()
+		delete(m.live, label)
+		m.mu.Unlock()
+
+		if m.stopped() {
+			return
+		}
+
+		open, ok := m.reopen[label]
+		if !ok {
+			return
+		}
+		newRC := m.reopenWithBackoff(label, open)
+		if newRC == nil {
+			return
+		}
+		rc = newRC
+
+		m.mu.Lock()
+		if m.stopping {
+			m.mu.Unlock()
+			rc.Close()
+			return
+		}
+		m.live[label] = rc
+		m.mu.Unlock()
+	}
+}
+
+func (m *logMultiplexer) copyLines(rc io.ReadCloser, label string) {
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		if m.stopped() {
+			return
+		}
+		if _, err := fmt.Fprintf(m.pw, "%s: %s\n", label, scanner.Text()); err != nil {
+			return
+		}
+	}
+}
+
+// reopenWithBackoff retries open until it succeeds or the multiplexer is stopped.
+func (m *logMultiplexer) reopenWithBackoff(label string, open func() (io.ReadCloser, error)) io.ReadCloser {
+	var result io.ReadCloser
+	run := func() (bool, error) {
+		if m.stopped() {
+			return true, nil
+		}
+		rc, err := open()
+		if err != nil {
+			return false, err
+		}
+		result = rc
+		return true, nil
+	}
+	bo := backoff.New(run, fmt.Sprintf("Kubernetes log stream (%s)", label))
+	bo.SetMaxBackoff(5 * time.Minute)
+	bo.Start()
+	return result
+}
+
+// discoverLoop periodically calls m.discover and starts streaming any label it returns that
+// isn't already live, so containers that appear after NewLogReadCloser was called (a sidecar,
+// a newly matching pod) get picked up without the caller asking again.
+func (m *logMultiplexer) discoverLoop() {
+	if m.discover == nil {
+		return
+	}
+	ticker := time.NewTicker(discoverInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.quit:
+			return
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		opens, err := m.discover()
+		if err != nil {
+			log.Errorf("kubernetes: error discovering log streams: %v", err)
+			continue
+		}
+		for label, open := range opens {
+			// Original readers reconnect through reopen in their own stream goroutine.
+			if _, ok := m.reopen[label]; ok {
+				continue
+			}
+			m.mu.Lock()
+			_, live := m.live[label]
+			m.mu.Unlock()
+			if live {
+				continue
+			}
+			rc, err := open()
+			if err != nil {
+				log.Errorf("kubernetes: error opening log stream %s: %v", label, err)
+				continue
+			}
+			m.startStream(rc, label)
+		}
+	}
+}
+
+// logReadCloser stops the multiplexer feeding it when closed, rather than just closing the
+// pipe and leaving its goroutines running until their next write.
+type logReadCloser struct {
+	*io.PipeReader
+	stop func()
+}
+
+func (l *logReadCloser) Close() error {
+	l.stop()
+	return l.PipeReader.Close()
+}